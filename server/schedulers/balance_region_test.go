@@ -0,0 +1,268 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/cache"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+	"github.com/pingcap/pd/server/schedule/mockcluster"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testBalanceRegionSchedulerSuite{})
+
+type testBalanceRegionSchedulerSuite struct{}
+
+func (s *testBalanceRegionSchedulerSuite) TestPopPendingOp(c *C) {
+	op, rest := popPendingOp(nil)
+	c.Assert(op, Equals, pendingBatchOp{})
+	c.Assert(rest, HasLen, 0)
+
+	ops := []pendingBatchOp{{op: &schedule.Operator{}, sourceID: 1, targetID: 2}, {op: &schedule.Operator{}, sourceID: 3, targetID: 4}}
+	first, rest := popPendingOp(ops)
+	c.Assert(first, Equals, ops[0])
+	c.Assert(rest, HasLen, 1)
+	c.Assert(rest[0], Equals, ops[1])
+}
+
+func (s *testBalanceRegionSchedulerSuite) TestPendingOpStillValid(c *C) {
+	tc := mockcluster.NewCluster()
+	tc.AddLeaderStore(1, 2)
+	tc.AddLeaderStore(2, 2)
+
+	c.Assert(pendingOpStillValid(tc, pendingBatchOp{sourceID: 1, targetID: 2}), Equals, true)
+	c.Assert(pendingOpStillValid(tc, pendingBatchOp{sourceID: 1, targetID: 99}), Equals, false)
+}
+
+func (s *testBalanceRegionSchedulerSuite) TestScheduleBatchFairness(c *C) {
+	tc := mockcluster.NewCluster()
+	tc.SetMaxReplicas(1)
+	tc.AddLeaderStore(1, 100)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderStore(3, 0)
+	for i := uint64(1); i <= 20; i++ {
+		tc.AddLeaderRegion(i, 1)
+	}
+
+	ttlCache := cache.NewIDTTL(storeCacheInterval, 4*storeCacheInterval)
+	sched := &balanceRegionScheduler{
+		cache:          ttlCache,
+		selector:       schedule.NewBalanceSelector(core.RegionKind, []schedule.Filter{schedule.NewCacheFilter(ttlCache), schedule.NewStateFilter(), schedule.NewHealthFilter()}),
+		weights:        dimensionWeights{cpu: 1, io: 1, network: 1},
+		batchSize:      4,
+		candidateCount: 3,
+	}
+
+	ops := sched.scheduleBatch(tc, schedule.OpInfluence{})
+	maxPerSource := fairSourceLimit(sched.batchSize)
+	counts := make(map[uint64]int)
+	for _, op := range ops {
+		counts[op.sourceID]++
+	}
+	for source, count := range counts {
+		c.Assert(count <= maxPerSource, Equals, true, Commentf("source %d got %d moves, want <= %d", source, count, maxPerSource))
+	}
+}
+
+func (s *testBalanceRegionSchedulerSuite) TestFairSourceLimit(c *C) {
+	c.Assert(fairSourceLimit(1), Equals, 1)
+	c.Assert(fairSourceLimit(2), Equals, 1)
+	c.Assert(fairSourceLimit(4), Equals, 2)
+}
+
+func (s *testBalanceRegionSchedulerSuite) TestNormalizedLoadScore(c *C) {
+	weights := dimensionWeights{cpu: 1, io: 1, network: 1}
+	// Equal weight on every dimension: the score is just the average delta.
+	c.Assert(normalizedLoadScore(0.3, 0.3, 0.3, weights), Equals, 0.3)
+	// No load data at all must stay neutral, not trip the veto.
+	c.Assert(normalizedLoadScore(0, 0, 0, weights), Equals, 0.0)
+	// All weight on zero still must not divide by zero.
+	c.Assert(normalizedLoadScore(0.5, 0.5, 0.5, dimensionWeights{}), Equals, 0.0)
+	// A tiny delta stays below minLoadImbalanceRatio.
+	c.Assert(normalizedLoadScore(0.01, 0.01, 0.01, weights) >= -minLoadImbalanceRatio, Equals, true)
+	// A large negative delta (target much hotter than source) crosses it.
+	c.Assert(normalizedLoadScore(-0.5, -0.5, -0.5, weights) >= -minLoadImbalanceRatio, Equals, false)
+}
+
+func (s *testBalanceRegionSchedulerSuite) TestShouldBalanceLoadVeto(c *C) {
+	tc := mockcluster.NewCluster()
+	tc.AddLeaderStore(1, 2)
+	tc.AddLeaderStore(2, 2)
+	defer func() {
+		storeLoadTable.Lock()
+		storeLoadTable.byStore = make(map[uint64]StoreLoad)
+		storeLoadTable.Unlock()
+	}()
+
+	sched := &balanceRegionScheduler{weights: dimensionWeights{cpu: 1, io: 1, network: 1}}
+	source := tc.GetStore(1)
+	target := tc.GetStore(2)
+
+	// No load reported yet: falls back to the size-only check, which
+	// passes here since target has much less data than source.
+	c.Assert(sched.shouldBalance(source, target, 100, 0, 10), Equals, true)
+
+	// Target much hotter than source: the load veto kicks in even though
+	// the size-only check alone would still approve the move.
+	SetStoreLoad(1, StoreLoad{CPUUsage: 0.1, IOUtil: 0.1, NetworkUsage: 0.1})
+	SetStoreLoad(2, StoreLoad{CPUUsage: 0.9, IOUtil: 0.9, NetworkUsage: 0.9})
+	c.Assert(sched.shouldBalance(source, target, 100, 0, 10), Equals, false)
+}
+
+type fakeRule struct {
+	name      string
+	hard      bool
+	violating bool
+}
+
+func (r *fakeRule) Name() string { return r.name }
+func (r *fakeRule) Hard() bool   { return r.hard }
+func (r *fakeRule) Violated(region *core.RegionInfo, candidate *core.StoreInfo, otherStores []*core.StoreInfo) bool {
+	return r.violating
+}
+
+func (s *testBalanceRegionSchedulerSuite) TestPlacementRulesRegistry(c *C) {
+	SetPlacementRules(nil)
+	c.Assert(placementRules(), HasLen, 0)
+
+	rules := []PlacementRule{&fakeRule{name: "hard-rack", hard: true, violating: true}}
+	SetPlacementRules(rules)
+	c.Assert(placementRules(), HasLen, 1)
+	c.Assert(placementRules()[0].Name(), Equals, "hard-rack")
+
+	SetPlacementRules(nil)
+}
+
+type hardOnStoreRule struct {
+	storeID uint64
+}
+
+func (r *hardOnStoreRule) Name() string { return "hard-on-store" }
+func (r *hardOnStoreRule) Hard() bool   { return true }
+func (r *hardOnStoreRule) Violated(region *core.RegionInfo, candidate *core.StoreInfo, otherStores []*core.StoreInfo) bool {
+	return candidate.GetId() == r.storeID
+}
+
+func (s *testBalanceRegionSchedulerSuite) TestPickMinCostCandidateHardViolation(c *C) {
+	tc := mockcluster.NewCluster()
+	tc.AddLeaderStore(1, 10)
+	tc.AddLeaderStore(2, 10)
+	tc.AddLeaderStore(3, 10)
+	tc.AddLeaderRegion(1, 1)
+
+	SetPlacementRules([]PlacementRule{&hardOnStoreRule{storeID: 2}})
+	defer SetPlacementRules(nil)
+
+	sched := &balanceRegionScheduler{candidateCount: 2}
+	region := tc.GetRegion(1)
+	source := tc.GetStore(1)
+	candidates := []*metapb.Peer{{StoreId: 2}, {StoreId: 3}}
+
+	newPeer, target, decision := sched.pickMinCostCandidate(tc, region, source, candidates, schedule.OpInfluence{}, newPendingInfluence())
+	c.Assert(newPeer, NotNil)
+	c.Assert(target.GetId(), Equals, uint64(3))
+	c.Assert(decision.Candidates, HasLen, 2)
+	for _, cand := range decision.Candidates {
+		switch cand.StoreID {
+		case 2:
+			c.Assert(cand.HardViolation, Equals, true)
+			c.Assert(cand.Picked, Equals, false)
+		case 3:
+			c.Assert(cand.HardViolation, Equals, false)
+			c.Assert(cand.Picked, Equals, true)
+		}
+	}
+}
+
+func (s *testBalanceRegionSchedulerSuite) TestCollectCandidatesNoDuplicates(c *C) {
+	tc := mockcluster.NewCluster()
+	tc.AddLeaderStore(1, 10)
+	tc.AddLeaderStore(2, 10)
+	tc.AddLeaderStore(3, 10)
+	tc.AddLeaderRegion(1, 1)
+
+	sched := &balanceRegionScheduler{candidateCount: 3}
+	region := tc.GetRegion(1)
+	source := tc.GetStore(1)
+	checker := schedule.NewReplicaChecker(tc, nil)
+
+	candidates := sched.collectCandidates(tc, checker, region, region.GetPeers()[0], tc.GetRegionStores(region), source)
+	seen := make(map[uint64]struct{}, len(candidates))
+	for _, cand := range candidates {
+		_, dup := seen[cand.GetStoreId()]
+		c.Assert(dup, Equals, false)
+		seen[cand.GetStoreId()] = struct{}{}
+	}
+}
+
+func (s *testBalanceRegionSchedulerSuite) TestPickMinCostCandidatePrefersLowerCost(c *C) {
+	tc := mockcluster.NewCluster()
+	tc.AddLeaderStore(1, 50)
+	tc.AddLeaderStore(2, 5)
+	tc.AddLeaderStore(3, 49)
+	tc.AddLeaderRegion(1, 1)
+
+	sched := &balanceRegionScheduler{candidateCount: 2}
+	region := tc.GetRegion(1)
+	source := tc.GetStore(1)
+	candidates := []*metapb.Peer{{StoreId: 2}, {StoreId: 3}}
+
+	// Both candidate stores report the same region size (0), so
+	// sizeImbalance and bytesToMove tie; store 3's leader count (49) is
+	// much closer to source's (50) than store 2's (5) is, so it has the
+	// smaller leaderImbalance and should win on cost.
+	newPeer, target, decision := sched.pickMinCostCandidate(tc, region, source, candidates, schedule.OpInfluence{}, newPendingInfluence())
+	c.Assert(newPeer, NotNil)
+	c.Assert(target.GetId(), Equals, uint64(3))
+	c.Assert(decision.Candidates, HasLen, 2)
+}
+
+func (s *testBalanceRegionSchedulerSuite) TestBalanceRegionDecisionsHandler(c *C) {
+	decisionLog.Lock()
+	decisionLog.entries = nil
+	decisionLog.Unlock()
+
+	recordDecision(BalanceRegionDecision{
+		RegionID: 1,
+		SourceID: 2,
+		Candidates: []BalanceRegionCandidate{
+			{StoreID: 3, Cost: 0.5, Picked: true},
+		},
+	})
+
+	w := httptest.NewRecorder()
+	BalanceRegionDecisionsHandler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	c.Assert(w.Code, Equals, http.StatusOK)
+
+	var got []BalanceRegionDecision
+	c.Assert(json.Unmarshal(w.Body.Bytes(), &got), IsNil)
+	c.Assert(got, HasLen, 1)
+	c.Assert(got[0].RegionID, Equals, uint64(1))
+	c.Assert(got[0].Candidates[0].Picked, Equals, true)
+
+	decisionLog.Lock()
+	decisionLog.entries = nil
+	decisionLog.Unlock()
+}